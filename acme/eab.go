@@ -0,0 +1,180 @@
+package acme
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by DB lookups when the requested object does
+// not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnauthorized is returned when an External Account Binding key can no
+// longer be used to bind a new ACME account, e.g. because it has expired
+// or been revoked.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ExternalAccountKey is an ACME External Account Binding (EAB) key bound
+// to a provisioner, and optionally to the ACME account it was used to
+// create.
+type ExternalAccountKey struct {
+	ID          string    `json:"id"`
+	Provisioner string    `json:"provisioner"`
+	Reference   string    `json:"reference"`
+	AccountID   string    `json:"accountID"`
+	KeyBytes    []byte    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+	BoundAt     time.Time `json:"boundAt"`
+	ExpiresAt   time.Time `json:"expiresAt,omitempty"`
+	RotatedAt   time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt   time.Time `json:"revokedAt,omitempty"`
+}
+
+// Bound reports whether the key has already been used to create an ACME
+// account.
+func (k *ExternalAccountKey) Bound() bool {
+	return k.AccountID != ""
+}
+
+// Expired reports whether the key has an expiration set and it has
+// passed as of now.
+func (k *ExternalAccountKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *ExternalAccountKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// Validate checks that the key can still be used to bind a new ACME
+// account as of now. This is the hook the ACME newAccount handler must
+// consult before accepting an EAB-bound account creation request.
+func (k *ExternalAccountKey) Validate(now time.Time) error {
+	switch {
+	case k.Revoked():
+		return fmt.Errorf("%w: external account binding key %q has been revoked", ErrUnauthorized, k.Reference)
+	case k.Expired(now):
+		return fmt.Errorf("%w: external account binding key %q has expired", ErrUnauthorized, k.Reference)
+	default:
+		return nil
+	}
+}
+
+// ExternalAccountKeyPageOptions describes the pagination and filtering
+// parameters accepted by DB.GetExternalAccountKeysPage.
+type ExternalAccountKeyPageOptions struct {
+	// Limit caps the number of keys returned; when it's zero or negative,
+	// every remaining key is returned.
+	Limit int
+	// Cursor resumes a previous listing; it's opaque to callers and
+	// should be treated as an implementation detail of the DB backend.
+	Cursor string
+	// Bound, when non-nil, restricts results to keys that have (or
+	// haven't) been used to create an ACME account.
+	Bound           *bool
+	ReferencePrefix string
+	CreatedBefore   time.Time
+	CreatedAfter    time.Time
+}
+
+// DB is the persistence interface for ACME External Account Binding keys.
+type DB interface {
+	CreateExternalAccountKey(ctx context.Context, provisionerName, reference string, expiresAt time.Time) (*ExternalAccountKey, error)
+	GetExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*ExternalAccountKey, error)
+	GetExternalAccountKeyByReference(ctx context.Context, provisionerName, reference string) (*ExternalAccountKey, error)
+	GetExternalAccountKeys(ctx context.Context, provisionerName string) ([]*ExternalAccountKey, error)
+	GetExternalAccountKeysPage(ctx context.Context, provisionerName string, opts ExternalAccountKeyPageOptions) ([]*ExternalAccountKey, string, error)
+	RotateExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*ExternalAccountKey, error)
+	RevokeExternalAccountKey(ctx context.Context, provisionerName, keyID string) error
+	ImportExternalAccountKey(ctx context.Context, provisionerName, reference string, keyBytes []byte) (*ExternalAccountKey, error)
+	DeleteExternalAccountKey(ctx context.Context, provisionerName, keyID string) error
+	// DeleteExpiredUnboundExternalAccountKeys deletes unbound keys whose
+	// ExpiresAt is before cutoff, and returns how many were deleted. It
+	// backs the EAB sweeper.
+	DeleteExpiredUnboundExternalAccountKeys(ctx context.Context, provisionerName string, cutoff time.Time) (int, error)
+}
+
+// FilterExternalAccountKeysPage applies opts' filters to keys, which must
+// already be sorted by ID, and returns the requested page along with the
+// cursor for the next one, or an empty string once the last page has been
+// returned. DB backends list their full (provisioner-scoped) key set and
+// delegate the filtering/paging logic here to avoid re-implementing it
+// per backend.
+func FilterExternalAccountKeysPage(keys []*ExternalAccountKey, opts ExternalAccountKeyPageOptions) ([]*ExternalAccountKey, string, error) {
+	filtered := make([]*ExternalAccountKey, 0, len(keys))
+	for _, k := range keys {
+		if opts.Bound != nil && k.Bound() != *opts.Bound {
+			continue
+		}
+		if opts.ReferencePrefix != "" && !strings.HasPrefix(k.Reference, opts.ReferencePrefix) {
+			continue
+		}
+		if !opts.CreatedBefore.IsZero() && !k.CreatedAt.Before(opts.CreatedBefore) {
+			continue
+		}
+		if !opts.CreatedAfter.IsZero() && !k.CreatedAt.After(opts.CreatedAfter) {
+			continue
+		}
+		filtered = append(filtered, k)
+	}
+
+	start := len(filtered)
+	if opts.Cursor == "" {
+		start = 0
+	} else {
+		lastID, err := DecodeEABCursor(opts.Cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid cursor: %w", err)
+		}
+		for i, k := range filtered {
+			if k.ID > lastID {
+				start = i
+				break
+			}
+		}
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = len(filtered) - start
+	}
+	end := start + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	page := filtered[start:end]
+	var next string
+	if end < len(filtered) {
+		next = EncodeEABCursor(page[len(page)-1].ID)
+	}
+	return page, next, nil
+}
+
+// EncodeEABCursor encodes id as the opaque cursor returned to API callers.
+func EncodeEABCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+// DecodeEABCursor decodes a cursor produced by EncodeEABCursor.
+func DecodeEABCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// NewEABKeyBytes generates fresh HMAC key material for an External
+// Account Binding key.
+func NewEABKeyBytes() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}
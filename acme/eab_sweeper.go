@@ -0,0 +1,87 @@
+package acme
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultEABSweepInterval = time.Hour
+
+// EABSweeper periodically deletes expired, unbound External Account
+// Binding keys so they don't accumulate indefinitely.
+type EABSweeper struct {
+	db       DB
+	interval time.Duration
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+
+	startedMu sync.Mutex
+	started   bool
+}
+
+// NewEABSweeper returns an EABSweeper backed by db, sweeping every
+// interval, or defaultEABSweepInterval when interval is zero.
+func NewEABSweeper(db DB, interval time.Duration) *EABSweeper {
+	if interval <= 0 {
+		interval = defaultEABSweepInterval
+	}
+	return &EABSweeper{
+		db:       db,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Start runs the sweep loop in the background for the given provisioner
+// names, until Stop is called or ctx is done. Start is a no-op if the
+// sweeper is already running.
+func (s *EABSweeper) Start(ctx context.Context, provisionerNames []string) {
+	s.startedMu.Lock()
+	defer s.startedMu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.run(ctx, provisionerNames)
+}
+
+func (s *EABSweeper) run(ctx context.Context, provisionerNames []string) {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx, provisionerNames)
+		}
+	}
+}
+
+func (s *EABSweeper) sweep(ctx context.Context, provisionerNames []string) {
+	now := time.Now()
+	for _, prov := range provisionerNames {
+		_, _ = s.db.DeleteExpiredUnboundExternalAccountKeys(ctx, prov, now)
+	}
+}
+
+// Stop terminates the sweep loop and waits for it to exit. Stop is
+// idempotent and safe to call even if Start was never called.
+func (s *EABSweeper) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+
+	s.startedMu.Lock()
+	started := s.started
+	s.startedMu.Unlock()
+	if started {
+		<-s.doneCh
+	}
+}
@@ -0,0 +1,91 @@
+package acme
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterExternalAccountKeysPage(t *testing.T) {
+	keys := []*ExternalAccountKey{
+		{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"},
+	}
+
+	page, next, err := FilterExternalAccountKeysPage(keys, ExternalAccountKeyPageOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(page); got != "a,b" {
+		t.Fatalf("first page = %q, want a,b", got)
+	}
+	if next == "" {
+		t.Fatal("expected a non-empty cursor for the first page")
+	}
+
+	page, next, err = FilterExternalAccountKeysPage(keys, ExternalAccountKeyPageOptions{Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(page); got != "c,d" {
+		t.Fatalf("second page = %q, want c,d", got)
+	}
+	if next == "" {
+		t.Fatal("expected a non-empty cursor for the second page")
+	}
+
+	page, next, err = FilterExternalAccountKeysPage(keys, ExternalAccountKeyPageOptions{Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(page); got != "e" {
+		t.Fatalf("last page = %q, want e", got)
+	}
+	if next != "" {
+		t.Fatalf("expected an empty cursor once the last page is exhausted, got %q", next)
+	}
+}
+
+func TestFilterExternalAccountKeysPageBound(t *testing.T) {
+	keys := []*ExternalAccountKey{
+		{ID: "a", AccountID: "acct-1"},
+		{ID: "b"},
+	}
+
+	bound := true
+	page, _, err := FilterExternalAccountKeysPage(keys, ExternalAccountKeyPageOptions{Bound: &bound})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := ids(page); got != "a" {
+		t.Fatalf("bound page = %q, want a", got)
+	}
+}
+
+func TestExternalAccountKeyValidate(t *testing.T) {
+	now := time.Now()
+
+	revoked := &ExternalAccountKey{Reference: "revoked", RevokedAt: now}
+	if err := revoked.Validate(now); err == nil {
+		t.Fatal("expected revoked key to fail validation")
+	}
+
+	expired := &ExternalAccountKey{Reference: "expired", ExpiresAt: now.Add(-time.Minute)}
+	if err := expired.Validate(now); err == nil {
+		t.Fatal("expected expired key to fail validation")
+	}
+
+	valid := &ExternalAccountKey{Reference: "valid", ExpiresAt: now.Add(time.Hour)}
+	if err := valid.Validate(now); err != nil {
+		t.Fatalf("unexpected error validating a live key: %v", err)
+	}
+}
+
+func ids(keys []*ExternalAccountKey) string {
+	s := ""
+	for i, k := range keys {
+		if i > 0 {
+			s += ","
+		}
+		s += k.ID
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+// Package memory provides an in-memory implementation of the ACME
+// External Account Binding key store, for tests and small deployments
+// that don't need a persistent backend.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smallstep/certificates/acme"
+)
+
+// DB is an in-memory implementation of the External Account Binding key
+// portion of acme.DB.
+type DB struct {
+	mu   sync.Mutex
+	keys map[string]*acme.ExternalAccountKey // provisionerName + "/" + id -> key
+	refs map[string]string                   // provisionerName + "/" + reference -> id
+}
+
+// New returns an empty in-memory External Account Binding key store.
+func New() *DB {
+	return &DB{
+		keys: make(map[string]*acme.ExternalAccountKey),
+		refs: make(map[string]string),
+	}
+}
+
+func recordKey(provisionerName, id string) string     { return provisionerName + "/" + id }
+func refKey(provisionerName, reference string) string { return provisionerName + "/" + reference }
+
+func (d *DB) CreateExternalAccountKey(ctx context.Context, provisionerName, reference string, expiresAt time.Time) (*acme.ExternalAccountKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	eak := &acme.ExternalAccountKey{
+		ID:          uuid.New().String(),
+		Provisioner: provisionerName,
+		Reference:   reference,
+		KeyBytes:    acme.NewEABKeyBytes(),
+		CreatedAt:   time.Now(),
+		ExpiresAt:   expiresAt,
+	}
+	d.keys[recordKey(provisionerName, eak.ID)] = eak
+	if reference != "" {
+		d.refs[refKey(provisionerName, reference)] = eak.ID
+	}
+	return eak, nil
+}
+
+func (d *DB) GetExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*acme.ExternalAccountKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	eak, ok := d.keys[recordKey(provisionerName, keyID)]
+	if !ok {
+		return nil, acme.ErrNotFound
+	}
+	return eak, nil
+}
+
+func (d *DB) GetExternalAccountKeyByReference(ctx context.Context, provisionerName, reference string) (*acme.ExternalAccountKey, error) {
+	if reference == "" {
+		return nil, acme.ErrNotFound
+	}
+
+	d.mu.Lock()
+	id, ok := d.refs[refKey(provisionerName, reference)]
+	d.mu.Unlock()
+	if !ok {
+		return nil, acme.ErrNotFound
+	}
+	return d.GetExternalAccountKey(ctx, provisionerName, id)
+}
+
+// listAll returns provisionerName's keys sorted by ID. Callers must hold d.mu.
+func (d *DB) listAll(provisionerName string) []*acme.ExternalAccountKey {
+	prefix := provisionerName + "/"
+	keys := make([]*acme.ExternalAccountKey, 0, len(d.keys))
+	for k, eak := range d.keys {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, eak)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+func (d *DB) GetExternalAccountKeys(ctx context.Context, provisionerName string) ([]*acme.ExternalAccountKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.listAll(provisionerName), nil
+}
+
+func (d *DB) GetExternalAccountKeysPage(ctx context.Context, provisionerName string, opts acme.ExternalAccountKeyPageOptions) ([]*acme.ExternalAccountKey, string, error) {
+	d.mu.Lock()
+	all := d.listAll(provisionerName)
+	d.mu.Unlock()
+	return acme.FilterExternalAccountKeysPage(all, opts)
+}
+
+func (d *DB) RotateExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*acme.ExternalAccountKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	eak, ok := d.keys[recordKey(provisionerName, keyID)]
+	if !ok {
+		return nil, acme.ErrNotFound
+	}
+	eak.KeyBytes = acme.NewEABKeyBytes()
+	eak.RotatedAt = time.Now()
+	return eak, nil
+}
+
+func (d *DB) RevokeExternalAccountKey(ctx context.Context, provisionerName, keyID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	eak, ok := d.keys[recordKey(provisionerName, keyID)]
+	if !ok {
+		return acme.ErrNotFound
+	}
+	eak.RevokedAt = time.Now()
+	return nil
+}
+
+func (d *DB) ImportExternalAccountKey(ctx context.Context, provisionerName, reference string, keyBytes []byte) (*acme.ExternalAccountKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if reference != "" {
+		if _, ok := d.refs[refKey(provisionerName, reference)]; ok {
+			return nil, fmt.Errorf("an external account key for reference %q already exists", reference)
+		}
+	}
+
+	eak := &acme.ExternalAccountKey{
+		ID:          uuid.New().String(),
+		Provisioner: provisionerName,
+		Reference:   reference,
+		KeyBytes:    keyBytes,
+		CreatedAt:   time.Now(),
+	}
+	if len(eak.KeyBytes) == 0 {
+		eak.KeyBytes = acme.NewEABKeyBytes()
+	}
+	d.keys[recordKey(provisionerName, eak.ID)] = eak
+	if reference != "" {
+		d.refs[refKey(provisionerName, reference)] = eak.ID
+	}
+	return eak, nil
+}
+
+func (d *DB) DeleteExternalAccountKey(ctx context.Context, provisionerName, keyID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	eak, ok := d.keys[recordKey(provisionerName, keyID)]
+	if !ok {
+		return acme.ErrNotFound
+	}
+	delete(d.keys, recordKey(provisionerName, keyID))
+	if eak.Reference != "" {
+		delete(d.refs, refKey(provisionerName, eak.Reference))
+	}
+	return nil
+}
+
+func (d *DB) DeleteExpiredUnboundExternalAccountKeys(ctx context.Context, provisionerName string, cutoff time.Time) (int, error) {
+	d.mu.Lock()
+	all := d.listAll(provisionerName)
+	d.mu.Unlock()
+
+	var deleted int
+	for _, k := range all {
+		if k.Bound() || k.ExpiresAt.IsZero() || !k.ExpiresAt.Before(cutoff) {
+			continue
+		}
+		if err := d.DeleteExternalAccountKey(ctx, provisionerName, k.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
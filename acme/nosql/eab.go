@@ -0,0 +1,262 @@
+// Package nosql provides a nosql-backed implementation of the ACME
+// External Account Binding key store.
+package nosql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smallstep/certificates/acme"
+	"github.com/smallstep/nosql"
+)
+
+var (
+	externalAccountKeysTable    = []byte("acme_eab_keys")
+	externalAccountKeyRefsTable = []byte("acme_eab_key_refs")
+)
+
+// DB is a nosql-backed implementation of the External Account Binding key
+// portion of acme.DB.
+type DB struct {
+	db nosql.DB
+}
+
+// New returns a nosql-backed acme.DB for External Account Binding keys.
+func New(db nosql.DB) *DB {
+	return &DB{db: db}
+}
+
+func recordKey(provisionerName, id string) []byte {
+	return []byte(provisionerName + "/" + id)
+}
+
+func refKey(provisionerName, reference string) []byte {
+	return []byte(provisionerName + "/" + reference)
+}
+
+// dbExternalAccountKey is the on-disk representation of an
+// acme.ExternalAccountKey. acme.ExternalAccountKey itself tags KeyBytes
+// with `json:"-"` so the HMAC key never leaks into an API response; this
+// type drops that tag so save/unmarshal actually persists it.
+type dbExternalAccountKey struct {
+	ID          string    `json:"id"`
+	Provisioner string    `json:"provisioner"`
+	Reference   string    `json:"reference"`
+	AccountID   string    `json:"accountID"`
+	KeyBytes    []byte    `json:"key"`
+	CreatedAt   time.Time `json:"createdAt"`
+	BoundAt     time.Time `json:"boundAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	RotatedAt   time.Time `json:"rotatedAt"`
+	RevokedAt   time.Time `json:"revokedAt"`
+}
+
+func toDBExternalAccountKey(eak *acme.ExternalAccountKey) *dbExternalAccountKey {
+	return &dbExternalAccountKey{
+		ID:          eak.ID,
+		Provisioner: eak.Provisioner,
+		Reference:   eak.Reference,
+		AccountID:   eak.AccountID,
+		KeyBytes:    eak.KeyBytes,
+		CreatedAt:   eak.CreatedAt,
+		BoundAt:     eak.BoundAt,
+		ExpiresAt:   eak.ExpiresAt,
+		RotatedAt:   eak.RotatedAt,
+		RevokedAt:   eak.RevokedAt,
+	}
+}
+
+func (dbeak *dbExternalAccountKey) toACME() *acme.ExternalAccountKey {
+	return &acme.ExternalAccountKey{
+		ID:          dbeak.ID,
+		Provisioner: dbeak.Provisioner,
+		Reference:   dbeak.Reference,
+		AccountID:   dbeak.AccountID,
+		KeyBytes:    dbeak.KeyBytes,
+		CreatedAt:   dbeak.CreatedAt,
+		BoundAt:     dbeak.BoundAt,
+		ExpiresAt:   dbeak.ExpiresAt,
+		RotatedAt:   dbeak.RotatedAt,
+		RevokedAt:   dbeak.RevokedAt,
+	}
+}
+
+func (d *DB) save(eak *acme.ExternalAccountKey) error {
+	b, err := json.Marshal(toDBExternalAccountKey(eak))
+	if err != nil {
+		return fmt.Errorf("failed marshaling external account key: %w", err)
+	}
+	if err := d.db.Set(externalAccountKeysTable, recordKey(eak.Provisioner, eak.ID), b); err != nil {
+		return fmt.Errorf("failed storing external account key: %w", err)
+	}
+	if eak.Reference != "" {
+		if err := d.db.Set(externalAccountKeyRefsTable, refKey(eak.Provisioner, eak.Reference), []byte(eak.ID)); err != nil {
+			return fmt.Errorf("failed storing external account key reference: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) CreateExternalAccountKey(ctx context.Context, provisionerName, reference string, expiresAt time.Time) (*acme.ExternalAccountKey, error) {
+	eak := &acme.ExternalAccountKey{
+		ID:          uuid.New().String(),
+		Provisioner: provisionerName,
+		Reference:   reference,
+		KeyBytes:    acme.NewEABKeyBytes(),
+		CreatedAt:   time.Now(),
+		ExpiresAt:   expiresAt,
+	}
+	if err := d.save(eak); err != nil {
+		return nil, err
+	}
+	return eak, nil
+}
+
+func (d *DB) GetExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*acme.ExternalAccountKey, error) {
+	b, err := d.db.Get(externalAccountKeysTable, recordKey(provisionerName, keyID))
+	switch {
+	case errors.Is(err, nosql.ErrNotFound):
+		return nil, acme.ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed retrieving external account key: %w", err)
+	}
+	var dbeak dbExternalAccountKey
+	if err := json.Unmarshal(b, &dbeak); err != nil {
+		return nil, fmt.Errorf("failed unmarshaling external account key: %w", err)
+	}
+	return dbeak.toACME(), nil
+}
+
+func (d *DB) GetExternalAccountKeyByReference(ctx context.Context, provisionerName, reference string) (*acme.ExternalAccountKey, error) {
+	if reference == "" {
+		return nil, acme.ErrNotFound
+	}
+	id, err := d.db.Get(externalAccountKeyRefsTable, refKey(provisionerName, reference))
+	switch {
+	case errors.Is(err, nosql.ErrNotFound):
+		return nil, acme.ErrNotFound
+	case err != nil:
+		return nil, fmt.Errorf("failed retrieving external account key reference: %w", err)
+	}
+	return d.GetExternalAccountKey(ctx, provisionerName, string(id))
+}
+
+func (d *DB) listAll(provisionerName string) ([]*acme.ExternalAccountKey, error) {
+	entries, err := d.db.List(externalAccountKeysTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing external account keys: %w", err)
+	}
+
+	prefix := provisionerName + "/"
+	keys := make([]*acme.ExternalAccountKey, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.HasPrefix(string(entry.Key), prefix) {
+			continue
+		}
+		var dbeak dbExternalAccountKey
+		if err := json.Unmarshal(entry.Value, &dbeak); err != nil {
+			return nil, fmt.Errorf("failed unmarshaling external account key: %w", err)
+		}
+		keys = append(keys, dbeak.toACME())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (d *DB) GetExternalAccountKeys(ctx context.Context, provisionerName string) ([]*acme.ExternalAccountKey, error) {
+	return d.listAll(provisionerName)
+}
+
+func (d *DB) GetExternalAccountKeysPage(ctx context.Context, provisionerName string, opts acme.ExternalAccountKeyPageOptions) ([]*acme.ExternalAccountKey, string, error) {
+	all, err := d.listAll(provisionerName)
+	if err != nil {
+		return nil, "", err
+	}
+	return acme.FilterExternalAccountKeysPage(all, opts)
+}
+
+func (d *DB) RotateExternalAccountKey(ctx context.Context, provisionerName, keyID string) (*acme.ExternalAccountKey, error) {
+	eak, err := d.GetExternalAccountKey(ctx, provisionerName, keyID)
+	if err != nil {
+		return nil, err
+	}
+	eak.KeyBytes = acme.NewEABKeyBytes()
+	eak.RotatedAt = time.Now()
+	if err := d.save(eak); err != nil {
+		return nil, err
+	}
+	return eak, nil
+}
+
+func (d *DB) RevokeExternalAccountKey(ctx context.Context, provisionerName, keyID string) error {
+	eak, err := d.GetExternalAccountKey(ctx, provisionerName, keyID)
+	if err != nil {
+		return err
+	}
+	eak.RevokedAt = time.Now()
+	return d.save(eak)
+}
+
+func (d *DB) ImportExternalAccountKey(ctx context.Context, provisionerName, reference string, keyBytes []byte) (*acme.ExternalAccountKey, error) {
+	if existing, err := d.GetExternalAccountKeyByReference(ctx, provisionerName, reference); err != nil && !errors.Is(err, acme.ErrNotFound) {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("an external account key for reference %q already exists", reference)
+	}
+
+	eak := &acme.ExternalAccountKey{
+		ID:          uuid.New().String(),
+		Provisioner: provisionerName,
+		Reference:   reference,
+		KeyBytes:    keyBytes,
+		CreatedAt:   time.Now(),
+	}
+	if len(eak.KeyBytes) == 0 {
+		eak.KeyBytes = acme.NewEABKeyBytes()
+	}
+	if err := d.save(eak); err != nil {
+		return nil, err
+	}
+	return eak, nil
+}
+
+func (d *DB) DeleteExternalAccountKey(ctx context.Context, provisionerName, keyID string) error {
+	eak, err := d.GetExternalAccountKey(ctx, provisionerName, keyID)
+	if err != nil {
+		return err
+	}
+	if err := d.db.Del(externalAccountKeysTable, recordKey(provisionerName, keyID)); err != nil {
+		return fmt.Errorf("failed deleting external account key: %w", err)
+	}
+	if eak.Reference != "" {
+		if err := d.db.Del(externalAccountKeyRefsTable, refKey(provisionerName, eak.Reference)); err != nil {
+			return fmt.Errorf("failed deleting external account key reference: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) DeleteExpiredUnboundExternalAccountKeys(ctx context.Context, provisionerName string, cutoff time.Time) (int, error) {
+	all, err := d.listAll(provisionerName)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	for _, k := range all {
+		if k.Bound() || k.ExpiresAt.IsZero() || !k.ExpiresAt.Before(cutoff) {
+			continue
+		}
+		if err := d.DeleteExternalAccountKey(ctx, provisionerName, k.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,45 @@
+package nosql
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/smallstep/certificates/acme"
+)
+
+func TestDBExternalAccountKeyPreservesKeyBytes(t *testing.T) {
+	eak := &acme.ExternalAccountKey{
+		ID:          "key-id",
+		Provisioner: "my-wire",
+		Reference:   "ref",
+		KeyBytes:    []byte("super-secret-hmac-key"),
+		CreatedAt:   time.Now().Truncate(time.Second),
+	}
+
+	// acme.ExternalAccountKey tags KeyBytes `json:"-"`, so marshaling it
+	// directly for storage would silently drop the secret; save() must go
+	// through dbExternalAccountKey instead.
+	if b, _ := json.Marshal(eak); bytes.Contains(b, eak.KeyBytes) {
+		t.Fatal("test setup invalid: acme.ExternalAccountKey unexpectedly serializes KeyBytes")
+	}
+
+	b, err := json.Marshal(toDBExternalAccountKey(eak))
+	if err != nil {
+		t.Fatalf("unexpected error marshaling: %v", err)
+	}
+
+	var dbeak dbExternalAccountKey
+	if err := json.Unmarshal(b, &dbeak); err != nil {
+		t.Fatalf("unexpected error unmarshaling: %v", err)
+	}
+
+	got := dbeak.toACME()
+	if !bytes.Equal(got.KeyBytes, eak.KeyBytes) {
+		t.Fatalf("KeyBytes = %q, want %q", got.KeyBytes, eak.KeyBytes)
+	}
+	if got.ID != eak.ID || got.Provisioner != eak.Provisioner || got.Reference != eak.Reference {
+		t.Fatalf("round-tripped key = %+v, want %+v", got, eak)
+	}
+}
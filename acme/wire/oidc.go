@@ -0,0 +1,38 @@
+package wire
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/smallstep/certificates/acme"
+	wireopts "github.com/smallstep/certificates/authority/provisioner/wire"
+)
+
+// ValidateOIDCChallenge verifies idToken against the provisioner's OIDC
+// options, checks that eak is still usable, and returns the claims to
+// certify on the account: userinfo-merged, when the provisioner enables
+// it, and passed through the provisioner's transform template.
+func ValidateOIDCChallenge(ctx context.Context, opts *wireopts.OIDCOptions, eak *acme.ExternalAccountKey, idToken, accessToken string) (map[string]any, error) {
+	if eak != nil {
+		if err := eak.Validate(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	verifier, err := opts.GetVerifier(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed getting OIDC verifier: %w", err)
+	}
+	idt, err := verifier.Verify(ctx, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed verifying ID token: %w", err)
+	}
+
+	var claims map[string]any
+	if err := idt.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed unmarshaling ID token claims: %w", err)
+	}
+
+	return opts.EvaluateAndTransform(ctx, accessToken, claims)
+}
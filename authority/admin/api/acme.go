@@ -1,10 +1,15 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/smallstep/certificates/acme"
@@ -18,6 +23,13 @@ import (
 // CreateExternalAccountKeyRequest is the type for POST /admin/acme/eab requests
 type CreateExternalAccountKeyRequest struct {
 	Reference string `json:"reference"`
+	// ExpiresAt, if set, is the time at which the EAB key expires and can
+	// no longer be used to bind a new ACME account.
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+	// TTL is an alternative to ExpiresAt for callers that would rather
+	// specify a duration (e.g. "24h") than compute an absolute timestamp
+	// themselves. A request that sets both ExpiresAt and TTL is rejected.
+	TTL string `json:"ttl,omitempty"`
 }
 
 // Validate validates a new ACME EAB Key request body.
@@ -25,12 +37,92 @@ func (r *CreateExternalAccountKeyRequest) Validate() error {
 	if len(r.Reference) > 256 { // an arbitrary, but sensible (IMO), limit
 		return fmt.Errorf("reference length %d exceeds the maximum (256)", len(r.Reference))
 	}
+	if !r.ExpiresAt.IsZero() && r.TTL != "" {
+		return errors.New("expiresAt and ttl are mutually exclusive")
+	}
+	if r.TTL != "" {
+		if _, err := time.ParseDuration(r.TTL); err != nil {
+			return fmt.Errorf("invalid ttl %q: %w", r.TTL, err)
+		}
+	}
 	return nil
 }
 
+// expiresAt resolves the expiration time to store for the EAB key, giving
+// ExpiresAt precedence over TTL when both somehow end up set.
+func (r *CreateExternalAccountKeyRequest) expiresAt(now time.Time) (time.Time, error) {
+	if !r.ExpiresAt.IsZero() {
+		return r.ExpiresAt, nil
+	}
+	if r.TTL == "" {
+		return time.Time{}, nil
+	}
+	ttl, err := time.ParseDuration(r.TTL)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ttl %q: %w", r.TTL, err)
+	}
+	return now.Add(ttl), nil
+}
+
 // GetExternalAccountKeysResponse is the type for GET /admin/acme/eab responses
 type GetExternalAccountKeysResponse struct {
 	EAKs []*linkedca.EABKey `json:"eaks"`
+	// NextCursor is an opaque cursor for fetching the next page of
+	// results, and is empty once the last page has been returned.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	defaultEABKeyPageLimit = 100
+	maxEABKeyPageLimit     = 1000
+)
+
+// parseEABKeyPageOptions extracts the pagination and filtering options for
+// GET /admin/acme/eab/{prov} from the request's query parameters.
+func parseEABKeyPageOptions(r *http.Request) (acme.ExternalAccountKeyPageOptions, error) {
+	q := r.URL.Query()
+
+	opts := acme.ExternalAccountKeyPageOptions{
+		Limit:           defaultEABKeyPageLimit,
+		Cursor:          q.Get("cursor"),
+		ReferencePrefix: q.Get("reference_prefix"),
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("invalid limit %q", limit)
+		}
+		opts.Limit = n
+	}
+	if opts.Limit > maxEABKeyPageLimit {
+		opts.Limit = maxEABKeyPageLimit
+	}
+
+	if bound := q.Get("bound"); bound != "" {
+		b, err := strconv.ParseBool(bound)
+		if err != nil {
+			return opts, fmt.Errorf("invalid bound %q: %w", bound, err)
+		}
+		opts.Bound = &b
+	}
+
+	if before := q.Get("created_before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return opts, fmt.Errorf("invalid created_before %q: %w", before, err)
+		}
+		opts.CreatedBefore = t
+	}
+	if after := q.Get("created_after"); after != "" {
+		t, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			return opts, fmt.Errorf("invalid created_after %q: %w", after, err)
+		}
+		opts.CreatedAfter = t
+	}
+
+	return opts, nil
 }
 
 // requireEABEnabled is a middleware that ensures ACME EAB is enabled
@@ -115,7 +207,13 @@ func (h *Handler) CreateExternalAccountKey(w http.ResponseWriter, r *http.Reques
 		// continue execution if no key was found for the reference
 	}
 
-	eak, err := h.acmeDB.CreateExternalAccountKey(r.Context(), prov, reference)
+	expiresAt, err := body.expiresAt(time.Now())
+	if err != nil {
+		api.WriteError(w, admin.WrapError(admin.ErrorBadRequestType, err, "error validating request body"))
+		return
+	}
+
+	eak, err := h.acmeDB.CreateExternalAccountKey(r.Context(), prov, reference, expiresAt)
 	if err != nil {
 		msg := fmt.Sprintf("error creating ACME EAB key for provisioner '%s'", prov)
 		if reference != "" {
@@ -131,10 +229,50 @@ func (h *Handler) CreateExternalAccountKey(w http.ResponseWriter, r *http.Reques
 		Provisioner: eak.Provisioner,
 		Reference:   eak.Reference,
 	}
+	if !eak.ExpiresAt.IsZero() {
+		response.ExpiresAt = timestamppb.New(eak.ExpiresAt)
+	}
 
 	api.ProtoJSONStatus(w, response, http.StatusCreated)
 }
 
+// RotateExternalAccountKey rotates the HMAC key bytes of an existing ACME
+// EAB key. The id and reference are preserved; the new secret is returned
+// exactly once, in the same response shape as CreateExternalAccountKey.
+func (h *Handler) RotateExternalAccountKey(w http.ResponseWriter, r *http.Request) {
+	prov := chi.URLParam(r, "prov")
+	keyID := chi.URLParam(r, "id")
+
+	eak, err := h.acmeDB.RotateExternalAccountKey(r.Context(), prov, keyID)
+	if err != nil {
+		api.WriteError(w, admin.WrapErrorISE(err, "error rotating ACME EAB key '%s'", keyID))
+		return
+	}
+
+	response := &linkedca.EABKey{
+		Id:          eak.ID,
+		HmacKey:     eak.KeyBytes,
+		Provisioner: eak.Provisioner,
+		Reference:   eak.Reference,
+	}
+
+	api.ProtoJSONStatus(w, response, http.StatusOK)
+}
+
+// RevokeExternalAccountKey marks an ACME EAB key as revoked, so that any
+// future ACME newAccount request presenting it is rejected.
+func (h *Handler) RevokeExternalAccountKey(w http.ResponseWriter, r *http.Request) {
+	prov := chi.URLParam(r, "prov")
+	keyID := chi.URLParam(r, "id")
+
+	if err := h.acmeDB.RevokeExternalAccountKey(r.Context(), prov, keyID); err != nil {
+		api.WriteError(w, admin.WrapErrorISE(err, "error revoking ACME EAB key '%s'", keyID))
+		return
+	}
+
+	api.JSON(w, &DeleteResponse{Status: "ok"})
+}
+
 // DeleteExternalAccountKey deletes an ACME External Account Key.
 func (h *Handler) DeleteExternalAccountKey(w http.ResponseWriter, r *http.Request) {
 	prov := chi.URLParam(r, "prov")
@@ -156,9 +294,10 @@ func (h *Handler) GetExternalAccountKeys(w http.ResponseWriter, r *http.Request)
 	reference := chi.URLParam(r, "ref")
 
 	var (
-		key  *acme.ExternalAccountKey
-		keys []*acme.ExternalAccountKey
-		err  error
+		key        *acme.ExternalAccountKey
+		keys       []*acme.ExternalAccountKey
+		nextCursor string
+		err        error
 	)
 
 	if reference != "" {
@@ -170,7 +309,12 @@ func (h *Handler) GetExternalAccountKeys(w http.ResponseWriter, r *http.Request)
 			keys = []*acme.ExternalAccountKey{key}
 		}
 	} else {
-		if keys, err = h.acmeDB.GetExternalAccountKeys(r.Context(), prov); err != nil {
+		var opts acme.ExternalAccountKeyPageOptions
+		if opts, err = parseEABKeyPageOptions(r); err != nil {
+			api.WriteError(w, admin.WrapError(admin.ErrorBadRequestType, err, "error parsing query parameters"))
+			return
+		}
+		if keys, nextCursor, err = h.acmeDB.GetExternalAccountKeysPage(r.Context(), prov, opts); err != nil {
 			api.WriteError(w, admin.WrapErrorISE(err, "error retrieving external account keys"))
 			return
 		}
@@ -187,9 +331,106 @@ func (h *Handler) GetExternalAccountKeys(w http.ResponseWriter, r *http.Request)
 			CreatedAt:   timestamppb.New(k.CreatedAt),
 			BoundAt:     timestamppb.New(k.BoundAt),
 		}
+		if !k.ExpiresAt.IsZero() {
+			eaks[i].ExpiresAt = timestamppb.New(k.ExpiresAt)
+		}
+		if !k.RotatedAt.IsZero() {
+			eaks[i].RotatedAt = timestamppb.New(k.RotatedAt)
+		}
+		if !k.RevokedAt.IsZero() {
+			eaks[i].RevokedAt = timestamppb.New(k.RevokedAt)
+		}
 	}
 
 	api.JSON(w, &GetExternalAccountKeysResponse{
-		EAKs: eaks,
+		EAKs:       eaks,
+		NextCursor: nextCursor,
 	})
 }
+
+// ImportExternalAccountKeyRequest is a single row accepted by
+// ImportExternalAccountKeys, either as an element of a JSON array or as
+// one line of a newline-delimited JSON (NDJSON) body.
+type ImportExternalAccountKeyRequest struct {
+	Reference string `json:"reference"`
+	HmacKey   []byte `json:"hmac_key,omitempty"`
+}
+
+// ImportExternalAccountKeyResult reports the outcome of importing a
+// single ImportExternalAccountKeyRequest row.
+type ImportExternalAccountKeyResult struct {
+	Reference string `json:"reference"`
+	ID        string `json:"id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportExternalAccountKeysResponse is the type for POST
+// /admin/acme/eab/{prov}/bulk responses.
+type ImportExternalAccountKeysResponse struct {
+	Results []ImportExternalAccountKeyResult `json:"results"`
+}
+
+// ImportExternalAccountKeys bulk-imports ACME EAB keys for a provisioner,
+// so operators can migrate an existing EAB corpus from another CA in one
+// call. The body may be a JSON array or NDJSON (one object per line) of
+// {reference, hmac_key?} rows; each row is imported independently, so a
+// failure on one row doesn't abort the rest.
+func (h *Handler) ImportExternalAccountKeys(w http.ResponseWriter, r *http.Request) {
+	prov := chi.URLParam(r, "prov")
+
+	rows, err := parseImportExternalAccountKeyRequests(r)
+	if err != nil {
+		api.WriteError(w, admin.WrapError(admin.ErrorBadRequestType, err, "error reading request body"))
+		return
+	}
+
+	results := make([]ImportExternalAccountKeyResult, len(rows))
+	for i, row := range rows {
+		result := ImportExternalAccountKeyResult{Reference: row.Reference}
+		eak, err := h.acmeDB.ImportExternalAccountKey(r.Context(), prov, row.Reference, row.HmacKey)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.ID = eak.ID
+		}
+		results[i] = result
+	}
+
+	api.JSON(w, &ImportExternalAccountKeysResponse{Results: results})
+}
+
+// parseImportExternalAccountKeyRequests reads the bulk-import request
+// body, accepting either a single JSON array or NDJSON (one object per
+// line).
+func parseImportExternalAccountKeyRequests(r *http.Request) ([]ImportExternalAccountKeyRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, errors.New("request body must not be empty")
+	}
+
+	if body[0] == '[' {
+		var rows []ImportExternalAccountKeyRequest
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("error unmarshaling request body: %w", err)
+		}
+		return rows, nil
+	}
+
+	var rows []ImportExternalAccountKeyRequest
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var row ImportExternalAccountKeyRequest
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, fmt.Errorf("error unmarshaling NDJSON row %q: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
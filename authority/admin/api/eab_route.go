@@ -0,0 +1,15 @@
+package api
+
+import "github.com/smallstep/certificates/api"
+
+// RouteEAB registers the ACME External Account Binding key endpoints on
+// r.
+func (h *Handler) RouteEAB(r api.Router) {
+	r.MethodFunc("POST", "/admin/acme/eab/{prov}", h.requireEABEnabled(h.CreateExternalAccountKey))
+	r.MethodFunc("POST", "/admin/acme/eab/{prov}/bulk", h.requireEABEnabled(h.ImportExternalAccountKeys))
+	r.MethodFunc("GET", "/admin/acme/eab/{prov}", h.requireEABEnabled(h.GetExternalAccountKeys))
+	r.MethodFunc("GET", "/admin/acme/eab/{prov}/{ref}", h.requireEABEnabled(h.GetExternalAccountKeys))
+	r.MethodFunc("PATCH", "/admin/acme/eab/{prov}/{id}", h.requireEABEnabled(h.RotateExternalAccountKey))
+	r.MethodFunc("POST", "/admin/acme/eab/{prov}/{id}/revoke", h.requireEABEnabled(h.RevokeExternalAccountKey))
+	r.MethodFunc("DELETE", "/admin/acme/eab/{prov}/{id}", h.requireEABEnabled(h.DeleteExternalAccountKey))
+}
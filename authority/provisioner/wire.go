@@ -0,0 +1,50 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smallstep/certificates/acme"
+	wireopts "github.com/smallstep/certificates/authority/provisioner/wire"
+)
+
+// Wire is the provisioner type for Wire's end-to-end identity device
+// enrollment flow. It layers Wire-specific OIDC and DPoP challenge
+// validation on top of the standard ACME provisioner.
+type Wire struct {
+	Name string                `json:"name"`
+	OIDC *wireopts.OIDCOptions `json:"oidc,omitempty"`
+
+	sweeper *acme.EABSweeper
+}
+
+// Init validates the provisioner's configuration and starts any
+// background resources it depends on, such as the OIDC provider's JWKS
+// refresh loop and, when db is non-nil, the sweep of this provisioner's
+// expired, unbound External Account Binding keys. It must be paired with
+// a call to Deinit.
+func (w *Wire) Init(db acme.DB) error {
+	if w.OIDC != nil {
+		if err := w.OIDC.Validate(); err != nil {
+			return fmt.Errorf("failed initializing wire provisioner %q: %w", w.Name, err)
+		}
+	}
+	if db != nil {
+		w.sweeper = acme.NewEABSweeper(db, 0)
+		w.sweeper.Start(context.Background(), []string{w.Name})
+	}
+	return nil
+}
+
+// Deinit stops any background resources started by Init. The provisioner
+// collection must call this whenever the provisioner is reloaded or
+// removed, so the OIDC JWKS refresh goroutine and the EAB sweeper don't
+// leak.
+func (w *Wire) Deinit() {
+	if w.OIDC != nil {
+		w.OIDC.Stop()
+	}
+	if w.sweeper != nil {
+		w.sweeper.Stop()
+	}
+}
@@ -0,0 +1,225 @@
+package wire
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+const (
+	defaultMinJWKSRefreshInterval = time.Minute
+	defaultMaxJWKSRefreshInterval = 24 * time.Hour
+	minForcedJWKSRefreshInterval  = 5 * time.Second
+)
+
+// oidcKeyManager periodically refreshes the JWKS served at a Wire IdP's
+// jwks_uri and keeps the [oidc.KeySet] used for ID token / DPoP token
+// verification up to date, so an IdP that rotates its signing keys
+// doesn't leave the CA rejecting valid tokens until it's restarted. It
+// implements [oidc.KeySet] itself, delegating signature verification to
+// whichever remote key set is currently active.
+type oidcKeyManager struct {
+	jwksURL     string
+	minInterval time.Duration
+	maxInterval time.Duration
+	httpClient  *http.Client
+
+	mu     sync.RWMutex
+	keySet oidc.KeySet
+
+	forceMu    sync.Mutex
+	lastForced time.Time
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+
+	startedMu sync.Mutex
+	started   bool
+}
+
+// newOIDCKeyManager constructs an oidcKeyManager for jwksURL. It does not
+// start the background refresh loop; call Start for that.
+func newOIDCKeyManager(ctx context.Context, jwksURL string, minInterval, maxInterval time.Duration) *oidcKeyManager {
+	if minInterval <= 0 {
+		minInterval = defaultMinJWKSRefreshInterval
+	}
+	if maxInterval <= 0 || maxInterval < minInterval {
+		maxInterval = defaultMaxJWKSRefreshInterval
+	}
+	return &oidcKeyManager{
+		jwksURL:     jwksURL,
+		minInterval: minInterval,
+		maxInterval: maxInterval,
+		httpClient:  http.DefaultClient,
+		keySet:      oidc.NewRemoteKeySet(ctx, jwksURL),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+}
+
+// VerifySignature implements oidc.KeySet. On a verification failure
+// caused by an unrecognized key ID, it triggers a rate-limited,
+// out-of-band refresh of the JWKS before returning the error, so keys
+// rolled by the IdP between scheduled refreshes are picked up without
+// waiting for the next tick.
+func (m *oidcKeyManager) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	payload, err := m.current().VerifySignature(ctx, jwt)
+	if err != nil && isUnknownKeyError(err) {
+		m.forceRefresh(ctx)
+		payload, err = m.current().VerifySignature(ctx, jwt)
+	}
+	return payload, err
+}
+
+func (m *oidcKeyManager) current() oidc.KeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keySet
+}
+
+func (m *oidcKeyManager) swap(ks oidc.KeySet) {
+	m.mu.Lock()
+	m.keySet = ks
+	m.mu.Unlock()
+}
+
+// forceRefresh swaps in a freshly constructed remote key set, rate
+// limited to at most once per minForcedJWKSRefreshInterval so a storm of
+// "unknown kid" verification failures can't turn into a JWKS-fetching
+// storm.
+func (m *oidcKeyManager) forceRefresh(ctx context.Context) {
+	m.forceMu.Lock()
+	defer m.forceMu.Unlock()
+	if time.Since(m.lastForced) < minForcedJWKSRefreshInterval {
+		return
+	}
+	m.lastForced = time.Now()
+	m.swap(oidc.NewRemoteKeySet(ctx, m.jwksURL))
+}
+
+// Start begins periodically refreshing the JWKS in the background,
+// honoring the Cache-Control/Expires headers of the JWKS response when
+// present, clamped to [minInterval, maxInterval]. It returns immediately;
+// the refresh loop runs until Stop is called or ctx is done. Start is a
+// no-op if the refresh loop is already running.
+func (m *oidcKeyManager) Start(ctx context.Context) {
+	m.startedMu.Lock()
+	defer m.startedMu.Unlock()
+	if m.started {
+		return
+	}
+	m.started = true
+	go m.run(ctx)
+}
+
+// Stop terminates the background refresh loop and waits for it to exit.
+// It is safe to call more than once, and safe to call even if Start was
+// never called.
+func (m *oidcKeyManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+
+	m.startedMu.Lock()
+	started := m.started
+	m.startedMu.Unlock()
+	if started {
+		<-m.doneCh
+	}
+}
+
+func (m *oidcKeyManager) run(ctx context.Context) {
+	defer close(m.doneCh)
+
+	timer := time.NewTimer(m.minInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(m.refresh(ctx))
+		}
+	}
+}
+
+// refresh re-fetches the JWKS, swaps it in, and returns how long to wait
+// before the next refresh.
+func (m *oidcKeyManager) refresh(ctx context.Context) time.Duration {
+	interval, err := m.fetchRefreshInterval(ctx)
+	if err != nil {
+		// Keep serving the previous key set; try again at the minimum
+		// interval rather than giving up on rotation entirely.
+		return m.minInterval
+	}
+	m.swap(oidc.NewRemoteKeySet(ctx, m.jwksURL))
+	return interval
+}
+
+// fetchRefreshInterval issues a HEAD request for the JWKS and derives the
+// next refresh interval from its Cache-Control max-age or Expires header,
+// clamped to [minInterval, maxInterval].
+func (m *oidcKeyManager) fetchRefreshInterval(ctx context.Context) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, m.jwksURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	interval := m.minInterval
+	if maxAge, ok := parseMaxAge(resp.Header.Get("Cache-Control")); ok {
+		interval = maxAge
+	} else if expires := resp.Header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			interval = time.Until(t)
+		}
+	}
+
+	if interval < m.minInterval {
+		interval = m.minInterval
+	}
+	if interval > m.maxInterval {
+		interval = m.maxInterval
+	}
+	return interval, nil
+}
+
+// parseMaxAge extracts the max-age directive, if any, from a
+// Cache-Control header value.
+func parseMaxAge(cacheControl string) (time.Duration, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// isUnknownKeyError reports whether err indicates that the JWT's key ID
+// wasn't found in the currently active key set. go-oidc's remoteKeySet
+// returns this as an unexported error of the form `oidc: key ID %q not
+// found`, so this pins to the "not found" substring; a future go-oidc
+// version that changes that wording would silently disable the
+// out-of-band refresh in VerifySignature above.
+func isUnknownKeyError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not found")
+}
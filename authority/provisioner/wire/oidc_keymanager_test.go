@@ -0,0 +1,43 @@
+package wire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsUnknownKeyError(t *testing.T) {
+	// Pins the exact wording go-oidc's remoteKeySet uses today
+	// (oidc.KeySet: "oidc: key ID %q not found"); see the doc comment on
+	// isUnknownKeyError.
+	if !isUnknownKeyError(fmt.Errorf("oidc: key ID %q not found", "abc123")) {
+		t.Fatal("expected the go-oidc unknown key ID error to match")
+	}
+	if isUnknownKeyError(errors.New("some other failure")) {
+		t.Fatal("did not expect an unrelated error to match")
+	}
+	if isUnknownKeyError(nil) {
+		t.Fatal("did not expect a nil error to match")
+	}
+}
+
+func TestOIDCKeyManagerStopWithoutStart(t *testing.T) {
+	m := newOIDCKeyManager(context.Background(), "https://idp.example.com/jwks", 0, 0)
+
+	// Must return promptly instead of blocking forever, since doneCh is
+	// only ever closed by run(), which Start would have spawned.
+	done := make(chan struct{})
+	go func() {
+		m.Stop()
+		m.Stop() // idempotent: must not panic on a second call either.
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() blocked even though Start was never called")
+	}
+}
@@ -7,11 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"go.step.sm/crypto/x509util"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 type Provider struct {
@@ -21,12 +24,31 @@ type Provider struct {
 	JWKSURL     string   `json:"jwks_uri,omitempty"`
 	UserInfoURL string   `json:"userinfo_endpoint,omitempty"`
 	Algorithms  []string `json:"id_token_signing_alg_values_supported,omitempty"`
+
+	// Discovery forces the provider endpoints to be fetched from the
+	// issuer's "/.well-known/openid-configuration" document, even if
+	// some of the fields above are already set. Fields set explicitly
+	// still take precedence over the discovered values.
+	Discovery bool `json:"discovery,omitempty"`
 }
 
 type Config struct {
 	ClientID            string   `json:"clientId,omitempty"`
 	SignatureAlgorithms []string `json:"signatureAlgorithms,omitempty"`
 
+	// UseUserInfo enables calling the provider's userinfo endpoint after
+	// ID token verification and merging the result into the claims passed
+	// to Transform. Opt-in, since it requires the IdP to expose
+	// Provider.UserInfoURL and adds a network round trip per request.
+	UseUserInfo bool `json:"useUserInfo,omitempty"`
+
+	// ClientSecret authenticates the client_credentials token request
+	// userInfoTokenSource falls back to when a request doesn't carry an
+	// access token alongside the ID token. When it's empty, there is no
+	// fallback: with UseUserInfo enabled, a request without an access
+	// token fails.
+	ClientSecret string `json:"clientSecret,omitempty"`
+
 	// the properties below are only used for testing
 	SkipClientIDCheck          bool             `json:"-"`
 	SkipExpiryCheck            bool             `json:"-"`
@@ -40,19 +62,59 @@ type OIDCOptions struct {
 	Config            *Config   `json:"config,omitempty"`
 	TransformTemplate string    `json:"transform,omitempty"`
 
-	target             *template.Template
-	transform          *template.Template
+	target    *template.Template
+	transform *template.Template
+
 	oidcProviderConfig *oidc.ProviderConfig
-	verifier           *oidc.IDTokenVerifier
+	oidcProvider       *oidc.Provider
+	keyManager         *oidcKeyManager
+
+	verifierOnce sync.Once
+	verifierMu   sync.RWMutex
+	verifier     *oidc.IDTokenVerifier
+	verifierErr  error
 }
 
+// GetVerifier returns the [oidc.IDTokenVerifier] for the provisioner,
+// building it from the (possibly discovered) provider configuration on
+// first use. It is safe for concurrent use; the verifier is only built
+// once and reused for every subsequent call, so discovery is never
+// repeated on the hot path of validating an ACME order. Signature
+// verification is backed by keyManager, which keeps the IdP's JWKS fresh
+// in the background.
 func (o *OIDCOptions) GetVerifier(ctx context.Context) (*oidc.IDTokenVerifier, error) {
-	if o.verifier == nil {
-		provider := o.oidcProviderConfig.NewProvider(ctx) // TODO: support the OIDC discovery flow
-		o.verifier = provider.Verifier(o.getConfig())
+	o.verifierMu.RLock()
+	verifier, err := o.verifier, o.verifierErr
+	o.verifierMu.RUnlock()
+	if verifier != nil || err != nil {
+		return verifier, err
 	}
 
-	return o.verifier, nil
+	o.verifierOnce.Do(func() {
+		o.verifierMu.Lock()
+		defer o.verifierMu.Unlock()
+
+		if o.oidcProviderConfig == nil || o.keyManager == nil {
+			o.verifierErr = errors.New("OIDC provider configuration not initialized")
+			return
+		}
+
+		o.oidcProvider = o.oidcProviderConfig.NewProvider(ctx)
+		o.verifier = oidc.NewVerifier(o.oidcProviderConfig.IssuerURL, o.keyManager, o.getConfig())
+	})
+
+	o.verifierMu.RLock()
+	defer o.verifierMu.RUnlock()
+	return o.verifier, o.verifierErr
+}
+
+// Stop terminates the background JWKS refresh loop started in
+// validateAndInitialize. It is called when the provisioner is reloaded
+// or removed so the goroutine doesn't leak.
+func (o *OIDCOptions) Stop() {
+	if o.keyManager != nil {
+		o.keyManager.Stop()
+	}
 }
 
 func (o *OIDCOptions) getConfig() *oidc.Config {
@@ -73,6 +135,14 @@ func (o *OIDCOptions) getConfig() *oidc.Config {
 
 const defaultTemplate = `{"name": "{{ .name }}", "preferred_username": "{{ .preferred_username }}"}`
 
+// Validate validates the OIDC options and initializes the provider
+// configuration, templates, and background JWKS refresh loop. It is the
+// entry point the owning provisioner calls from its own Init, and must be
+// paired with a call to Stop when the provisioner is reloaded or removed.
+func (o *OIDCOptions) Validate() error {
+	return o.validateAndInitialize()
+}
+
 func (o *OIDCOptions) validateAndInitialize() (err error) {
 	if o.Provider == nil {
 		return errors.New("provider not set")
@@ -81,9 +151,14 @@ func (o *OIDCOptions) validateAndInitialize() (err error) {
 		return errors.New("issuer URL must not be empty")
 	}
 
-	o.oidcProviderConfig, err = toOIDCProviderConfig(o.Provider)
+	o.oidcProviderConfig, err = resolveOIDCProviderConfig(context.Background(), o.Provider)
 	if err != nil {
-		return fmt.Errorf("failed creationg OIDC provider config: %w", err)
+		return fmt.Errorf("failed resolving OIDC provider config: %w", err)
+	}
+
+	if o.oidcProviderConfig.JWKSURL != "" {
+		o.keyManager = newOIDCKeyManager(context.Background(), o.oidcProviderConfig.JWKSURL, 0, 0)
+		o.keyManager.Start(context.Background())
 	}
 
 	o.target, err = template.New("DeviceID").Parse(o.Provider.IssuerURL)
@@ -140,20 +215,190 @@ func (o *OIDCOptions) Transform(v map[string]any) (map[string]any, error) {
 	return r, nil
 }
 
-func toOIDCProviderConfig(in *Provider) (*oidc.ProviderConfig, error) {
-	issuerURL, err := url.Parse(in.IssuerURL)
+// EvaluateAndTransform is the entry point callers should use once an ID
+// token has been verified: it merges userinfo claims into idTokenClaims
+// when Config.UseUserInfo is enabled, via GetUserInfoClaims, and then runs
+// the configured transform over the result. When UseUserInfo is disabled
+// this is equivalent to calling Transform directly.
+func (o *OIDCOptions) EvaluateAndTransform(ctx context.Context, accessToken string, idTokenClaims map[string]any) (map[string]any, error) {
+	claims, err := o.GetUserInfoClaims(ctx, accessToken, idTokenClaims)
 	if err != nil {
-		return nil, fmt.Errorf("failed parsing issuer URL: %w", err)
+		return nil, err
+	}
+	return o.Transform(claims)
+}
+
+// GetUserInfoClaims fetches the provider's userinfo endpoint, when
+// Config.UseUserInfo is enabled, and merges the result into claims, which
+// must already hold the verified ID token claims. ID-token claims win on
+// conflict, per the OIDC spec, and the merged userinfo claims are also
+// exposed under a "userinfo" key so templates can disambiguate, e.g.
+// `{{ .userinfo.email }}`. It fails closed if the userinfo response's
+// `sub` doesn't match the ID token's. If accessToken is empty, a
+// client_credentials token is obtained instead. When UseUserInfo is
+// disabled, claims is returned unmodified.
+func (o *OIDCOptions) GetUserInfoClaims(ctx context.Context, accessToken string, claims map[string]any) (map[string]any, error) {
+	if o.Config == nil || !o.Config.UseUserInfo {
+		return claims, nil
+	}
+
+	o.verifierMu.RLock()
+	provider, providerConfig := o.oidcProvider, o.oidcProviderConfig
+	o.verifierMu.RUnlock()
+	if provider == nil {
+		return nil, errors.New("OIDC provider not initialized")
+	}
+
+	ts, err := o.userInfoTokenSource(ctx, accessToken, providerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed preparing userinfo request: %w", err)
+	}
+
+	info, err := provider.UserInfo(ctx, ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving userinfo: %w", err)
+	}
+
+	var userinfo map[string]any
+	if err := info.Claims(&userinfo); err != nil {
+		return nil, fmt.Errorf("failed unmarshaling userinfo claims: %w", err)
+	}
+
+	if sub, ok := claims["sub"].(string); ok && sub != "" && info.Subject != sub {
+		return nil, fmt.Errorf("userinfo subject %q does not match ID token subject %q", info.Subject, sub)
+	}
+
+	merged := make(map[string]any, len(claims)+len(userinfo)+1)
+	for key, value := range userinfo {
+		merged[key] = value
+	}
+	for key, value := range claims {
+		merged[key] = value
+	}
+	merged["userinfo"] = userinfo
+
+	return merged, nil
+}
+
+// userInfoTokenSource returns a static token source for accessToken, or,
+// when the caller didn't present an access token alongside the ID token,
+// obtains a fresh one through the client_credentials grant, authenticated
+// with Config.ClientSecret.
+func (o *OIDCOptions) userInfoTokenSource(ctx context.Context, accessToken string, providerConfig *oidc.ProviderConfig) (oauth2.TokenSource, error) {
+	if accessToken != "" {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}), nil
+	}
+	if o.Config.ClientSecret == "" {
+		return nil, errors.New("no access token presented and Config.ClientSecret not set for client_credentials fallback")
+	}
+
+	cc := clientcredentials.Config{
+		ClientID:     o.Config.ClientID,
+		ClientSecret: o.Config.ClientSecret,
+		TokenURL:     providerConfig.TokenURL,
+	}
+	token, err := cc.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed obtaining client_credentials token: %w", err)
+	}
+	return oauth2.StaticTokenSource(token), nil
+}
+
+// needsDiscovery reports whether any of the endpoints required to build an
+// OIDC provider are missing, or discovery was explicitly requested.
+func needsDiscovery(in *Provider) bool {
+	return in.Discovery ||
+		in.AuthURL == "" ||
+		in.TokenURL == "" ||
+		in.JWKSURL == "" ||
+		in.UserInfoURL == "" ||
+		len(in.Algorithms) == 0
+}
+
+// resolveOIDCProviderConfig builds the [oidc.ProviderConfig] used to
+// construct the OIDC provider and, ultimately, the ID token verifier. When
+// the provider is missing one or more endpoints, or discovery is forced
+// through Provider.Discovery, it fetches the issuer's
+// "/.well-known/openid-configuration" document and merges the discovered
+// endpoints into the configuration, with any explicitly-configured field
+// taking precedence over the discovered value.
+func resolveOIDCProviderConfig(ctx context.Context, in *Provider) (*oidc.ProviderConfig, error) {
+	if !needsDiscovery(in) {
+		return toOIDCProviderConfig(in)
+	}
+
+	issuer, err := trimIssuerURL(in.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	discovered, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed discovering OIDC provider configuration for issuer %q: %w", issuer, err)
+	}
+
+	var claims struct {
+		Issuer      string   `json:"issuer"`
+		AuthURL     string   `json:"authorization_endpoint"`
+		TokenURL    string   `json:"token_endpoint"`
+		JWKSURL     string   `json:"jwks_uri"`
+		UserInfoURL string   `json:"userinfo_endpoint"`
+		Algorithms  []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := discovered.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed parsing discovered OIDC provider configuration: %w", err)
+	}
+	if claims.Issuer != "" && claims.Issuer != issuer {
+		return nil, fmt.Errorf("discovered issuer %q does not match configured issuer %q", claims.Issuer, issuer)
+	}
+
+	merged := &Provider{
+		IssuerURL:   in.IssuerURL,
+		AuthURL:     firstNonEmpty(in.AuthURL, claims.AuthURL),
+		TokenURL:    firstNonEmpty(in.TokenURL, claims.TokenURL),
+		JWKSURL:     firstNonEmpty(in.JWKSURL, claims.JWKSURL),
+		UserInfoURL: firstNonEmpty(in.UserInfoURL, claims.UserInfoURL),
+		Algorithms:  in.Algorithms,
+	}
+	if len(merged.Algorithms) == 0 {
+		merged.Algorithms = claims.Algorithms
+	}
+
+	return toOIDCProviderConfig(merged)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// trimIssuerURL strips the query and fragment from in, because we use the
+// query string to notify the client about the actual OAuth ClientID for this
+// provisioner. This URL is going to look like: "https://idp:5556/dex?clientid=foo"
+// If we don't trim the query params here i.e. 'clientid' then the idToken
+// verification is going to fail because the 'iss' claim of the idToken will
+// be "https://idp:5556/dex".
+func trimIssuerURL(in string) (string, error) {
+	issuerURL, err := url.Parse(in)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing issuer URL: %w", err)
 	}
-	// Removes query params from the URL because we use it as a way to notify client about the actual OAuth ClientId
-	// for this provisioner.
-	// This URL is going to look like: "https://idp:5556/dex?clientid=foo"
-	// If we don't trim the query params here i.e. 'clientid' then the idToken verification is going to fail because
-	// the 'iss' claim of the idToken will be "https://idp:5556/dex"
 	issuerURL.RawQuery = ""
 	issuerURL.Fragment = ""
+	return issuerURL.String(), nil
+}
+
+func toOIDCProviderConfig(in *Provider) (*oidc.ProviderConfig, error) {
+	issuerURL, err := trimIssuerURL(in.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
 	return &oidc.ProviderConfig{
-		IssuerURL:   issuerURL.String(),
+		IssuerURL:   issuerURL,
 		AuthURL:     in.AuthURL,
 		TokenURL:    in.TokenURL,
 		UserInfoURL: in.UserInfoURL,